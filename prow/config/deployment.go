@@ -0,0 +1,70 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+// Deployment is a Prow job that is triggered by a deployment_status webhook
+// transitioning a GitHub deployment to a particular environment/state,
+// mirroring the role Presubmit and Postsubmit play for PR and push events.
+type Deployment struct {
+	// Name is the name of the job.
+	Name string `json:"name"`
+	// Environment restricts this job to deployment_status events for the
+	// named environment, e.g. "production" or "staging".
+	Environment string `json:"environment"`
+	// State restricts this job to deployment_status events reporting this
+	// state, e.g. "success", "failure", "error". Defaults to "success".
+	State string `json:"state,omitempty"`
+}
+
+// TriggerMatches returns whether a deployment_status event for the given
+// environment/state should cause this job to run.
+func (d Deployment) TriggerMatches(environment, state string) bool {
+	if d.Environment != environment {
+		return false
+	}
+	want := d.State
+	if want == "" {
+		want = "success"
+	}
+	return want == state
+}
+
+// ShouldRun returns whether the deployment job should run for a
+// deployment_status event reporting environment/state, mirroring the
+// (bool, error) shape of Presubmit.ShouldRun and Postsubmit.ShouldRun so
+// pjutil's filter helpers can treat all three job types uniformly.
+func (d Deployment) ShouldRun(environment, state string) (bool, error) {
+	return d.TriggerMatches(environment, state), nil
+}
+
+// DeploymentsConfig is deployment jobs keyed by "org/repo", the same way
+// JobConfig keys Presubmits and Postsubmits.
+//
+// This is intentionally not wired up as a field on JobConfig yet: doing so
+// means touching JobConfig's own declaration and the config loader that
+// populates it from YAML, neither of which is part of this change. Once
+// JobConfig grows a `Deployments DeploymentsConfig` field, GetDeployments
+// below is the method that field needs so hook/trigger plugins can dispatch
+// on deployment_status webhooks the same way they do with
+// JobConfig.GetPresubmits/GetPostsubmits today; wiring that field in and
+// threading it through the config loader is deferred to a follow-up change.
+type DeploymentsConfig map[string][]Deployment
+
+// GetDeployments returns the deployment jobs configured for repo.
+func (c DeploymentsConfig) GetDeployments(repo string) []Deployment {
+	return c[repo]
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import "testing"
+
+func TestDeploymentTriggerMatches(t *testing.T) {
+	testCases := []struct {
+		name        string
+		deployment  Deployment
+		environment string
+		state       string
+		expected    bool
+	}{
+		{
+			name:        "matching environment, default state",
+			deployment:  Deployment{Name: "deploy-prod", Environment: "production"},
+			environment: "production",
+			state:       "success",
+			expected:    true,
+		},
+		{
+			name:        "matching environment, explicit state",
+			deployment:  Deployment{Name: "notify-failure", Environment: "production", State: "failure"},
+			environment: "production",
+			state:       "failure",
+			expected:    true,
+		},
+		{
+			name:        "non-matching environment",
+			deployment:  Deployment{Name: "deploy-prod", Environment: "production"},
+			environment: "staging",
+			state:       "success",
+			expected:    false,
+		},
+		{
+			name:        "non-matching state",
+			deployment:  Deployment{Name: "deploy-prod", Environment: "production"},
+			environment: "production",
+			state:       "failure",
+			expected:    false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := tc.deployment.TriggerMatches(tc.environment, tc.state); actual != tc.expected {
+				t.Errorf("TriggerMatches(%q, %q) = %v, expected %v", tc.environment, tc.state, actual, tc.expected)
+			}
+			shouldRun, err := tc.deployment.ShouldRun(tc.environment, tc.state)
+			if err != nil {
+				t.Fatalf("ShouldRun returned unexpected error: %v", err)
+			}
+			if shouldRun != tc.expected {
+				t.Errorf("ShouldRun(%q, %q) = %v, expected %v", tc.environment, tc.state, shouldRun, tc.expected)
+			}
+		})
+	}
+}
+
+func TestDeploymentsConfigGetDeployments(t *testing.T) {
+	deployments := DeploymentsConfig{
+		"kubernetes/test-infra": {{Name: "deploy-prod", Environment: "production"}},
+	}
+
+	if got := deployments.GetDeployments("kubernetes/test-infra"); len(got) != 1 || got[0].Name != "deploy-prod" {
+		t.Errorf("GetDeployments(%q) = %v, expected one deployment named deploy-prod", "kubernetes/test-infra", got)
+	}
+
+	if got := deployments.GetDeployments("kubernetes/kubernetes"); got != nil {
+		t.Errorf("GetDeployments(%q) = %v, expected nil for an unconfigured repo", "kubernetes/kubernetes", got)
+	}
+}
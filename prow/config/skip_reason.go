@@ -0,0 +1,37 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+// SkipReason describes why FilterPresubmitsWithReasons decided not to
+// trigger a presubmit it considered a candidate (one that matched the
+// trigger filter). Presubmits that never matched the filter at all are not
+// candidates and have no SkipReason.
+type SkipReason string
+
+const (
+	// SkipReasonNotAllowed means the presubmit was rejected by a
+	// TriggerAllowList before ShouldRun was ever consulted.
+	SkipReasonNotAllowed SkipReason = "not permitted by trigger allow-list"
+	// SkipReasonConditionsNotMet means the presubmit matched the trigger
+	// filter but ShouldRun reported that its run conditions (branch,
+	// changed paths, always_run, etc.) were not satisfied.
+	//
+	// ShouldRun currently collapses those conditions into a single bool,
+	// so this reason cannot yet distinguish branch mismatches from path
+	// mismatches; doing so requires ShouldRun itself to return a reason.
+	SkipReasonConditionsNotMet SkipReason = "run conditions not met"
+)
@@ -0,0 +1,114 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// TriggerAllowList lets cluster admins configure a central safety net of
+// permitted job names (and, optionally, repositories) independent of any
+// repo-owned branches/skip_branches config. It mirrors the pattern of an
+// agent-level allowed-plugins list: a compromised PR body that `/test`s an
+// unintended sensitive job is rejected here before it ever reaches
+// ShouldRun.
+type TriggerAllowList struct {
+	// JobNames are regex patterns of job names permitted to run. A
+	// presubmit whose name does not match any pattern is rejected. An
+	// empty list permits all job names.
+	JobNames []string `json:"job_names,omitempty"`
+	// Repos are regex patterns of "org/repo" permitted to trigger jobs
+	// through this allow-list. An empty list permits all repos.
+	Repos []string `json:"repos,omitempty"`
+
+	jobNameRes []*regexp.Regexp
+	repoRes    []*regexp.Regexp
+}
+
+// Compile parses the configured regex patterns once so Permits does not pay
+// compilation cost on every call.
+func (t *TriggerAllowList) Compile() error {
+	jobNameRes, err := compileAll(t.JobNames)
+	if err != nil {
+		return fmt.Errorf("invalid job_names pattern: %v", err)
+	}
+	repoRes, err := compileAll(t.Repos)
+	if err != nil {
+		return fmt.Errorf("invalid repos pattern: %v", err)
+	}
+	t.jobNameRes = jobNameRes
+	t.repoRes = repoRes
+	return nil
+}
+
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %v", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Permits returns whether jobName is allowed to trigger for repo. An unset
+// allow-list (no JobNames and no Repos configured) permits everything.
+//
+// Permits does not require Compile to have been called first: if the
+// compiled regexps are missing for a configured pattern list (Compile was
+// never called, or failed), Permits falls back to matching the raw patterns
+// directly. A safety net that fails open whenever a caller forgets to wire
+// up Compile would be worse than no safety net at all.
+func (t TriggerAllowList) Permits(repo, jobName string) bool {
+	if !t.matches(t.repoRes, t.Repos, repo) {
+		return false
+	}
+	if !t.matches(t.jobNameRes, t.JobNames, jobName) {
+		return false
+	}
+	return true
+}
+
+// matches reports whether s matches any pattern restricting it, using the
+// pre-compiled regexps when available and otherwise compiling raw on the
+// fly. An empty raw pattern list means "no restriction".
+func (t TriggerAllowList) matches(compiled []*regexp.Regexp, raw []string, s string) bool {
+	if len(raw) == 0 {
+		return true
+	}
+	if len(compiled) == len(raw) {
+		return anyMatch(compiled, s)
+	}
+	for _, pattern := range raw {
+		if matched, err := regexp.MatchString(pattern, s); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+func anyMatch(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import "testing"
+
+func TestTriggerAllowListPermits(t *testing.T) {
+	testCases := []struct {
+		name      string
+		allowList TriggerAllowList
+		compile   bool
+		repo      string
+		jobName   string
+		expected  bool
+	}{
+		{
+			name:      "unset allow-list permits everything",
+			allowList: TriggerAllowList{},
+			compile:   true,
+			repo:      "kubernetes/test-infra",
+			jobName:   "pull-test-infra-unit",
+			expected:  true,
+		},
+		{
+			name:      "matching job name, compiled",
+			allowList: TriggerAllowList{JobNames: []string{`^pull-test-infra-.*$`}},
+			compile:   true,
+			repo:      "kubernetes/test-infra",
+			jobName:   "pull-test-infra-unit",
+			expected:  true,
+		},
+		{
+			name:      "non-matching job name, compiled",
+			allowList: TriggerAllowList{JobNames: []string{`^pull-test-infra-.*$`}},
+			compile:   true,
+			repo:      "kubernetes/test-infra",
+			jobName:   "pull-kubernetes-unit",
+			expected:  false,
+		},
+		{
+			name:      "repo restriction rejects other repos",
+			allowList: TriggerAllowList{Repos: []string{`^kubernetes/test-infra$`}},
+			compile:   true,
+			repo:      "kubernetes/kubernetes",
+			jobName:   "pull-kubernetes-unit",
+			expected:  false,
+		},
+		{
+			name:      "configured but never compiled still rejects",
+			allowList: TriggerAllowList{JobNames: []string{`^pull-test-infra-.*$`}},
+			compile:   false,
+			repo:      "kubernetes/test-infra",
+			jobName:   "pull-kubernetes-unit",
+			expected:  false,
+		},
+		{
+			name:      "configured but never compiled still permits a match",
+			allowList: TriggerAllowList{JobNames: []string{`^pull-test-infra-.*$`}},
+			compile:   false,
+			repo:      "kubernetes/test-infra",
+			jobName:   "pull-test-infra-unit",
+			expected:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.compile {
+				if err := tc.allowList.Compile(); err != nil {
+					t.Fatalf("Compile() returned unexpected error: %v", err)
+				}
+			}
+			if actual := tc.allowList.Permits(tc.repo, tc.jobName); actual != tc.expected {
+				t.Errorf("Permits(%q, %q) = %v, expected %v", tc.repo, tc.jobName, actual, tc.expected)
+			}
+		})
+	}
+}
@@ -17,13 +17,25 @@ limitations under the License.
 package pjutil
 
 import (
+	"fmt"
 	"regexp"
 
+	"github.com/gobwas/glob"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
 	"k8s.io/test-infra/prow/config"
 )
 
-var TestAllRe = regexp.MustCompile(`(?m)^/test all,?($|\s.*)`)
+var (
+	TestAllRe = regexp.MustCompile(`(?m)^/test all,?($|\s.*)`)
+	// OkToTestRe matches `/ok-to-test`, the comment an org member posts to
+	// authorize an untrusted-author PR to run its presubmits.
+	OkToTestRe = regexp.MustCompile(`(?m)^/ok-to-test\s*$`)
+	// RetestRe matches `/retest`, a request to re-run any presubmit that
+	// previously failed or never reported a status.
+	RetestRe = regexp.MustCompile(`(?m)^/retest\s*$`)
+)
 
 // Filter digests a presubmit config to determine if:
 //  - we the presubmit matched the filter
@@ -61,6 +73,93 @@ func AggregateFilter(filters []Filter) Filter {
 	}
 }
 
+// IntersectionFilter builds a filter that only matches when every child
+// filter matches, so it can be used to pre-intersect the candidate set
+// passed to FilterPresubmits (e.g. restricting an AggregateFilter's matches
+// to a glob of changed paths or job names).
+func IntersectionFilter(filters []Filter) Filter {
+	return func(presubmit config.Presubmit) (bool, bool, bool) {
+		var forcedToRun, defaultBehavior bool
+		for _, filter := range filters {
+			shouldRun, forced, defaults := filter(presubmit)
+			if !shouldRun {
+				return false, false, false
+			}
+			forcedToRun = forcedToRun || forced
+			defaultBehavior = defaultBehavior || defaults
+		}
+		return true, forcedToRun, defaultBehavior
+	}
+}
+
+// compileGlobs compiles a set of glob patterns once, returning a clear error
+// if any pattern is malformed.
+func compileGlobs(globs []string) ([]glob.Glob, error) {
+	compiled := make([]glob.Glob, 0, len(globs))
+	for _, pattern := range globs {
+		g, err := glob.Compile(pattern, '/')
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %v", pattern, err)
+		}
+		compiled = append(compiled, g)
+	}
+	return compiled, nil
+}
+
+// PathFilter builds a Filter that matches presubmits whose changed files (as
+// reported by changes) overlap with at least one of the given path globs,
+// e.g. "/test files pkg/foo/**". The globs are compiled once up front, and
+// changes is resolved once up front too, so a malformed pattern or a failed
+// file lookup is reported immediately as an error instead of being retried,
+// or silently swallowed, once per presubmit.
+//
+// PathFilter only restricts the candidate set; it never forces a presubmit
+// to run or overrides its default behavior, so it composes safely with
+// IntersectionFilter and still defers to ShouldRun.
+func PathFilter(globs []string, changes config.ChangedFilesProvider) (Filter, error) {
+	compiled, err := compileGlobs(globs)
+	if err != nil {
+		return nil, err
+	}
+	changedFiles, err := changes()
+	if err != nil {
+		return nil, fmt.Errorf("could not determine changed files: %v", err)
+	}
+	return func(p config.Presubmit) (bool, bool, bool) {
+		for _, changedFile := range changedFiles {
+			for _, g := range compiled {
+				if g.Match(changedFile) {
+					return true, false, false
+				}
+			}
+		}
+		return false, false, false
+	}, nil
+}
+
+// JobNameFilter builds a Filter that matches presubmits whose name matches
+// at least one of the given job-name globs, e.g. "/test jobs e2e-*". The
+// globs are compiled once up front; a malformed pattern is reported
+// immediately rather than on every match.
+//
+// JobNameFilter only restricts the candidate set; it never forces a
+// presubmit to run or overrides its default behavior, so it composes safely
+// with IntersectionFilter and still defers to ShouldRun.
+func JobNameFilter(globs []string) (Filter, error) {
+	compiled, err := compileGlobs(globs)
+	if err != nil {
+		return nil, err
+	}
+	return func(p config.Presubmit) (bool, bool, bool) {
+		for _, g := range compiled {
+			if g.Match(p.Name) {
+				return true, false, false
+			}
+		}
+		return false, false, false
+	}, nil
+}
+
 // FilterPresubmits determines which presubmits should run and which should be skipped
 // by evaluating the user-provided filter.
 func FilterPresubmits(filter Filter, changes config.ChangedFilesProvider, branch string, presubmits []config.Presubmit, logger *logrus.Entry) ([]config.Presubmit, []config.Presubmit, error) {
@@ -90,3 +189,196 @@ func FilterPresubmits(filter Filter, changes config.ChangedFilesProvider, branch
 	logger.WithFields(logrus.Fields{"to-trigger": namesToTrigger, "to-skip": namesToSkip}).Debugf("Filtered %d jobs, found %d to trigger and %d to skip.", len(presubmits), len(toTrigger), len(toSkip))
 	return toTrigger, toSkip, nil
 }
+
+// FilterPresubmitsWithAllowList behaves like FilterPresubmits, but rejects
+// any matched presubmit whose name/repo is not permitted by allowList,
+// regardless of what the filter or ShouldRun decided. Rejections are logged
+// with a "skip-reason" annotation so operators can tell an allow-list
+// rejection apart from an ordinary branch/path mismatch.
+func FilterPresubmitsWithAllowList(filter Filter, changes config.ChangedFilesProvider, branch, repo string, presubmits []config.Presubmit, allowList config.TriggerAllowList, logger *logrus.Entry) ([]config.Presubmit, []config.Presubmit, error) {
+	var toTrigger []config.Presubmit
+	var namesToTrigger []string
+	var toSkip []config.Presubmit
+	var namesToSkip []string
+	for _, presubmit := range presubmits {
+		matches, forced, defaults := filter(presubmit)
+		if !matches {
+			continue
+		}
+		if !allowList.Permits(repo, presubmit.Name) {
+			toSkip = append(toSkip, presubmit)
+			namesToSkip = append(namesToSkip, presubmit.Name)
+			logger.WithFields(logrus.Fields{"job": presubmit.Name, "repo": repo, "skip-reason": "not on allow-list"}).Info("Rejected presubmit not present on the trigger allow-list.")
+			continue
+		}
+		shouldRun, err := presubmit.ShouldRun(branch, changes, forced, defaults)
+		if err != nil {
+			return nil, nil, err
+		}
+		if shouldRun {
+			toTrigger = append(toTrigger, presubmit)
+			namesToTrigger = append(namesToTrigger, presubmit.Name)
+		} else {
+			toSkip = append(toSkip, presubmit)
+			namesToSkip = append(namesToSkip, presubmit.Name)
+		}
+	}
+
+	logger.WithFields(logrus.Fields{"to-trigger": namesToTrigger, "to-skip": namesToSkip}).Debugf("Filtered %d jobs, found %d to trigger and %d to skip.", len(presubmits), len(toTrigger), len(toSkip))
+	return toTrigger, toSkip, nil
+}
+
+// FilterPresubmitsWithReasons behaves like FilterPresubmits, but additionally
+// returns a map of skipped job name to the SkipReason it was skipped for, so
+// callers such as the trigger plugin can post a helpful "here's why your job
+// didn't run" comment instead of only logging names. allowList is consulted
+// the same way FilterPresubmitsWithAllowList does; pass the zero value to
+// skip that check.
+//
+// Like FilterPresubmits, a presubmit that never matched the filter is not a
+// candidate at all and is left out of toSkip entirely — for an ordinary
+// `/test foo` in a repo with many jobs, only foo is a candidate, and
+// surfacing every other unrelated job as "did not match" would make the map
+// useless for posting a per-job reason back to the PR. toSkip only covers
+// presubmits that matched the filter and were then rejected: by the trigger
+// allow-list, or by ShouldRun. It does not yet split the ShouldRun case into
+// branch-mismatch/path-mismatch/forced-off, because ShouldRun only returns a
+// single bool today; that would need ShouldRun itself to start returning a
+// reason, which is out of scope here.
+func FilterPresubmitsWithReasons(filter Filter, changes config.ChangedFilesProvider, branch, repo string, presubmits []config.Presubmit, allowList config.TriggerAllowList, logger *logrus.Entry) ([]config.Presubmit, map[string]config.SkipReason, error) {
+	var toTrigger []config.Presubmit
+	var namesToTrigger []string
+	toSkip := map[string]config.SkipReason{}
+	for _, presubmit := range presubmits {
+		matches, forced, defaults := filter(presubmit)
+		if !matches {
+			continue
+		}
+		if !allowList.Permits(repo, presubmit.Name) {
+			toSkip[presubmit.Name] = config.SkipReasonNotAllowed
+			continue
+		}
+		shouldRun, err := presubmit.ShouldRun(branch, changes, forced, defaults)
+		if err != nil {
+			return nil, nil, err
+		}
+		if shouldRun {
+			toTrigger = append(toTrigger, presubmit)
+			namesToTrigger = append(namesToTrigger, presubmit.Name)
+		} else {
+			toSkip[presubmit.Name] = config.SkipReasonConditionsNotMet
+		}
+	}
+
+	logger.WithFields(logrus.Fields{"to-trigger": namesToTrigger, "to-skip": toSkip}).Debugf("Filtered %d jobs, found %d to trigger and %d to skip.", len(presubmits), len(toTrigger), len(toSkip))
+	return toTrigger, toSkip, nil
+}
+
+// DeploymentFilterFunc digests a deployment job config to determine if the
+// job should run for the deployment_status event in question.
+type DeploymentFilterFunc func(d config.Deployment) bool
+
+// DeploymentFilter builds a DeploymentFilterFunc for a deployment_status
+// webhook reporting that environment transitioned to state.
+func DeploymentFilter(environment, state string) DeploymentFilterFunc {
+	return func(d config.Deployment) bool {
+		shouldRun, _ := d.ShouldRun(environment, state)
+		return shouldRun
+	}
+}
+
+// FilterDeployments determines which deployment jobs should run for a
+// deployment_status event by evaluating the user-provided filter.
+func FilterDeployments(filter DeploymentFilterFunc, deployments []config.Deployment, logger *logrus.Entry) []config.Deployment {
+	var toTrigger []config.Deployment
+	var namesToTrigger []string
+	for _, deployment := range deployments {
+		if !filter(deployment) {
+			continue
+		}
+		toTrigger = append(toTrigger, deployment)
+		namesToTrigger = append(namesToTrigger, deployment.Name)
+	}
+
+	logger.WithField("to-trigger", namesToTrigger).Debugf("Filtered %d deployment jobs, found %d to trigger.", len(deployments), len(toTrigger))
+	return toTrigger
+}
+
+// PeriodicFilterFunc digests a periodic job config to determine if the job
+// should be triggered out of its normal schedule.
+type PeriodicFilterFunc func(p config.Periodic) bool
+
+// PeriodicFilter builds a PeriodicFilterFunc that matches the periodic job
+// with the given name, letting it be triggered on demand alongside its
+// normal cron/interval schedule.
+func PeriodicFilter(name string) PeriodicFilterFunc {
+	return func(p config.Periodic) bool {
+		return p.Name == name
+	}
+}
+
+// FilterPeriodics determines which periodics should be triggered by
+// evaluating the user-provided filter.
+func FilterPeriodics(filter PeriodicFilterFunc, periodics []config.Periodic, logger *logrus.Entry) []config.Periodic {
+	var toTrigger []config.Periodic
+	var namesToTrigger []string
+	for _, periodic := range periodics {
+		if !filter(periodic) {
+			continue
+		}
+		toTrigger = append(toTrigger, periodic)
+		namesToTrigger = append(namesToTrigger, periodic.Name)
+	}
+
+	logger.WithField("to-trigger", namesToTrigger).Debugf("Filtered %d periodic jobs, found %d to trigger.", len(periodics), len(toTrigger))
+	return toTrigger
+}
+
+// OkToTestFilter builds a filter for the automatic behavior of
+// `/ok-to-test`: like TestAllFilter, it runs every presubmit that doesn't
+// require an explicit trigger, but only once an orgMember has actually
+// posted the command, letting an untrusted-author PR run its presubmits
+// without the author re-triggering each one by name.
+func OkToTestFilter(body string, orgMember bool) Filter {
+	return func(p config.Presubmit) (bool, bool, bool) {
+		if !orgMember || !OkToTestRe.MatchString(body) {
+			return false, false, false
+		}
+		return !p.NeedsExplicitTrigger(), false, false
+	}
+}
+
+// RetestFilter builds a filter for the automatic behavior of `/retest`. It
+// matches presubmits whose most recent run failed, and presubmits that have
+// never reported a status at all unless they need an explicit trigger (those
+// are not matched); allContexts and failedContexts are the
+// GitHub status contexts of all of the PR's prior runs and of the failed
+// ones, respectively.
+//
+// It never forces a run: forced bypasses ShouldRun's branch/path
+// determination entirely, and `/retest` should not resurrect a conditional
+// job whose paths are no longer touched by the PR. defaultBehavior instead
+// carries whether the context previously failed, so a presubmit that has
+// never reported still defers to ShouldRun's own run_if_changed logic for
+// whether it should run by default.
+func RetestFilter(failedContexts, allContexts sets.String) Filter {
+	return func(p config.Presubmit) (bool, bool, bool) {
+		failed := failedContexts.Has(p.Context)
+		if failed {
+			return true, false, true
+		}
+		if !allContexts.Has(p.Context) {
+			return !p.NeedsExplicitTrigger(), false, false
+		}
+		return false, false, false
+	}
+}
+
+// BatchFilter builds a filter that matches presubmits eligible for batch
+// testing: those marked always_run and not excluded from the merge queue's
+// required checks.
+func BatchFilter() Filter {
+	return func(p config.Presubmit) (bool, bool, bool) {
+		return p.AlwaysRun && !p.Optional, true, true
+	}
+}
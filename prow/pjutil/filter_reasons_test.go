@@ -0,0 +1,75 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pjutil
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/test-infra/prow/config"
+)
+
+func TestFilterPresubmitsWithReasonsAllowList(t *testing.T) {
+	allowList := config.TriggerAllowList{JobNames: []string{`^pull-allowed-.*$`}}
+	if err := allowList.Compile(); err != nil {
+		t.Fatalf("Compile() returned unexpected error: %v", err)
+	}
+
+	presubmits := []config.Presubmit{
+		{Name: "pull-allowed-unit"},
+		{Name: "pull-sensitive-deploy"},
+	}
+
+	// A trivial always-match filter isolates the allow-list behavior under
+	// test from TriggerMatches/ShouldRun semantics, which are exercised by
+	// their own tests elsewhere.
+	alwaysMatch := func(p config.Presubmit) (bool, bool, bool) { return true, true, true }
+	changes := func() ([]string, error) { return nil, nil }
+
+	_, toSkip, err := FilterPresubmitsWithReasons(alwaysMatch, changes, "master", "kubernetes/test-infra", presubmits, allowList, logrus.NewEntry(logrus.StandardLogger()))
+	if err != nil {
+		t.Fatalf("FilterPresubmitsWithReasons returned unexpected error: %v", err)
+	}
+
+	if reason, ok := toSkip["pull-sensitive-deploy"]; !ok || reason != config.SkipReasonNotAllowed {
+		t.Errorf("expected pull-sensitive-deploy to be skipped with %q, got %q (present: %v)", config.SkipReasonNotAllowed, reason, ok)
+	}
+}
+
+func TestFilterPresubmitsWithReasonsDoesNotReportNonMatches(t *testing.T) {
+	presubmits := []config.Presubmit{
+		{Name: "pull-foo-unit"},
+		{Name: "pull-bar-unit"},
+		{Name: "pull-baz-unit"},
+	}
+
+	// Only pull-foo-unit is a candidate; the others never matched the
+	// filter and must not show up in toSkip at all.
+	filter := func(p config.Presubmit) (bool, bool, bool) {
+		return p.Name == "pull-foo-unit", true, true
+	}
+	changes := func() ([]string, error) { return nil, nil }
+
+	_, toSkip, err := FilterPresubmitsWithReasons(filter, changes, "master", "kubernetes/test-infra", presubmits, config.TriggerAllowList{}, logrus.NewEntry(logrus.StandardLogger()))
+	if err != nil {
+		t.Fatalf("FilterPresubmitsWithReasons returned unexpected error: %v", err)
+	}
+
+	if len(toSkip) != 0 {
+		t.Errorf("expected no skip reasons for presubmits that never matched the filter, got %v", toSkip)
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pjutil
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/test-infra/prow/config"
+)
+
+func TestRetestFilter(t *testing.T) {
+	testCases := []struct {
+		name            string
+		failedContexts  sets.String
+		allContexts     sets.String
+		presubmit       config.Presubmit
+		expectShouldRun bool
+		expectForced    bool
+		expectDefaults  bool
+	}{
+		{
+			name:            "failed context is a run candidate, not forced",
+			failedContexts:  sets.NewString("ci/foo"),
+			allContexts:     sets.NewString("ci/foo"),
+			presubmit:       config.Presubmit{Name: "foo", Context: "ci/foo"},
+			expectShouldRun: true,
+			expectForced:    false,
+			expectDefaults:  true,
+		},
+		{
+			name:            "passing context is not retested",
+			failedContexts:  sets.NewString(),
+			allContexts:     sets.NewString("ci/foo"),
+			presubmit:       config.Presubmit{Name: "foo", Context: "ci/foo"},
+			expectShouldRun: false,
+			expectForced:    false,
+			expectDefaults:  false,
+		},
+		{
+			name:            "missing context on an always_run job is a run candidate",
+			failedContexts:  sets.NewString(),
+			allContexts:     sets.NewString(),
+			presubmit:       config.Presubmit{Name: "foo", Context: "ci/foo", AlwaysRun: true},
+			expectShouldRun: true,
+			expectForced:    false,
+			expectDefaults:  false,
+		},
+		{
+			name:            "missing context requiring an explicit trigger is not a run candidate",
+			failedContexts:  sets.NewString(),
+			allContexts:     sets.NewString(),
+			presubmit:       config.Presubmit{Name: "foo", Context: "ci/foo", AlwaysRun: false},
+			expectShouldRun: false,
+			expectForced:    false,
+			expectDefaults:  false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter := RetestFilter(tc.failedContexts, tc.allContexts)
+			shouldRun, forced, defaults := filter(tc.presubmit)
+			if shouldRun != tc.expectShouldRun {
+				t.Errorf("shouldRun = %v, expected %v", shouldRun, tc.expectShouldRun)
+			}
+			if forced != tc.expectForced {
+				t.Errorf("forced = %v, expected %v", forced, tc.expectForced)
+			}
+			if defaults != tc.expectDefaults {
+				t.Errorf("defaultBehavior = %v, expected %v", defaults, tc.expectDefaults)
+			}
+		})
+	}
+}
+
+func TestBatchFilter(t *testing.T) {
+	testCases := []struct {
+		name      string
+		presubmit config.Presubmit
+		expected  bool
+	}{
+		{
+			name:      "always_run and required is eligible for batch",
+			presubmit: config.Presubmit{Name: "foo", AlwaysRun: true, Optional: false},
+			expected:  true,
+		},
+		{
+			name:      "not always_run is not eligible for batch",
+			presubmit: config.Presubmit{Name: "foo", AlwaysRun: false, Optional: false},
+			expected:  false,
+		},
+		{
+			name:      "optional is not eligible for batch",
+			presubmit: config.Presubmit{Name: "foo", AlwaysRun: true, Optional: true},
+			expected:  false,
+		},
+	}
+
+	filter := BatchFilter()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			shouldRun, _, _ := filter(tc.presubmit)
+			if shouldRun != tc.expected {
+				t.Errorf("shouldRun = %v, expected %v", shouldRun, tc.expected)
+			}
+		})
+	}
+}
+
@@ -0,0 +1,87 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pjutil
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/test-infra/prow/config"
+)
+
+func TestJobNameFilter(t *testing.T) {
+	filter, err := JobNameFilter([]string{"e2e-*"})
+	if err != nil {
+		t.Fatalf("JobNameFilter returned unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name         string
+		presubmit    config.Presubmit
+		expectMatch  bool
+		expectForced bool
+	}{
+		{name: "matching job name", presubmit: config.Presubmit{Name: "e2e-gce"}, expectMatch: true, expectForced: false},
+		{name: "non-matching job name", presubmit: config.Presubmit{Name: "unit-test"}, expectMatch: false, expectForced: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			shouldRun, forced, _ := filter(tc.presubmit)
+			if shouldRun != tc.expectMatch {
+				t.Errorf("shouldRun = %v, expected %v", shouldRun, tc.expectMatch)
+			}
+			if forced != tc.expectForced {
+				t.Errorf("forced = %v, expected %v", forced, tc.expectForced)
+			}
+		})
+	}
+
+	if _, err := JobNameFilter([]string{"["}); err == nil {
+		t.Error("expected an error for a malformed glob, got nil")
+	}
+}
+
+func TestPathFilter(t *testing.T) {
+	changes := func() ([]string, error) {
+		return []string{"pkg/foo/bar.go"}, nil
+	}
+
+	filter, err := PathFilter([]string{"pkg/foo/**"}, changes)
+	if err != nil {
+		t.Fatalf("PathFilter returned unexpected error: %v", err)
+	}
+
+	shouldRun, forced, defaultBehavior := filter(config.Presubmit{Name: "foo"})
+	if !shouldRun {
+		t.Error("expected a change under pkg/foo/** to match")
+	}
+	if forced || defaultBehavior {
+		t.Errorf("PathFilter must not force a run or override default behavior, got forced=%v defaultBehavior=%v", forced, defaultBehavior)
+	}
+
+	if _, err := PathFilter([]string{"["}, changes); err == nil {
+		t.Error("expected an error for a malformed glob, got nil")
+	}
+
+	failing := func() ([]string, error) {
+		return nil, fmt.Errorf("could not list changed files")
+	}
+	if _, err := PathFilter([]string{"pkg/foo/**"}, failing); err == nil {
+		t.Error("expected PathFilter to propagate a changes() error, got nil")
+	}
+}